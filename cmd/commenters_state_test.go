@@ -0,0 +1,132 @@
+/*
+Copyright © 2023 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStateFilePath(t *testing.T) {
+	if got, want := stateFilePath("out.csv"), "out.csv.state.json"; got != want {
+		t.Errorf("stateFilePath(%q) = %q, want %q", "out.csv", got, want)
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	path := writeTempFile(t, "prs.csv", "org,repository,number\njenkinsci,jenkins,1\n")
+
+	h1, err := hashFile(path)
+	if err != nil {
+		t.Fatalf("hashFile returned %v", err)
+	}
+	h2, err := hashFile(path)
+	if err != nil {
+		t.Fatalf("hashFile returned %v", err)
+	}
+	if h1 != h2 {
+		t.Error("hashFile is not deterministic for identical content")
+	}
+
+	other := writeTempFile(t, "other.csv", "org,repository,number\njenkinsci,jenkins,2\n")
+	if h3, err := hashFile(other); err != nil {
+		t.Fatalf("hashFile returned %v", err)
+	} else if h3 == h1 {
+		t.Error("hashFile collided for different content")
+	}
+}
+
+func TestHashFileMissing(t *testing.T) {
+	if _, err := hashFile(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Fatal("expected an error for a missing file, got nil")
+	}
+}
+
+func TestLoadStateNoSidecar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv.state.json")
+
+	state, resumed := loadState(path, "somehash")
+	if resumed {
+		t.Error("loadState reported resumed = true with no sidecar file")
+	}
+	if state.ProcessedKeys == nil {
+		t.Error("loadState returned a nil ProcessedKeys map")
+	}
+}
+
+func TestSaveStateThenLoadStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv.state.json")
+	const inputHash = "abc123"
+
+	saved := &commentersState{
+		InputHash:        inputHash,
+		LastProcessedKey: "jenkinsci/jenkins/1",
+		ProcessedKeys:    map[string]bool{"jenkinsci/jenkins/1": true},
+		TotalComments:    5,
+	}
+	if err := saveState(path, saved); err != nil {
+		t.Fatalf("saveState returned %v", err)
+	}
+
+	loaded, resumed := loadState(path, inputHash)
+	if !resumed {
+		t.Fatal("loadState reported resumed = false for a matching input hash")
+	}
+	if !loaded.ProcessedKeys["jenkinsci/jenkins/1"] {
+		t.Error("loaded state lost the processed key")
+	}
+	if loaded.TotalComments != 5 {
+		t.Errorf("loaded.TotalComments = %d, want 5", loaded.TotalComments)
+	}
+}
+
+func TestLoadStateRejectsChangedInput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv.state.json")
+
+	if err := saveState(path, &commentersState{InputHash: "old-hash", ProcessedKeys: map[string]bool{"x": true}}); err != nil {
+		t.Fatalf("saveState returned %v", err)
+	}
+
+	state, resumed := loadState(path, "new-hash")
+	if resumed {
+		t.Error("loadState reported resumed = true despite a changed input hash")
+	}
+	if len(state.ProcessedKeys) != 0 {
+		t.Error("loadState carried over processed keys from a stale, input-mismatched state file")
+	}
+}
+
+func TestLoadStateRejectsMalformedSidecar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv.state.json")
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+
+	state, resumed := loadState(path, "somehash")
+	if resumed {
+		t.Error("loadState reported resumed = true for a malformed sidecar file")
+	}
+	if state.ProcessedKeys == nil {
+		t.Error("loadState returned a nil ProcessedKeys map for a malformed sidecar file")
+	}
+}
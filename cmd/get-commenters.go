@@ -22,13 +22,18 @@ THE SOFTWARE.
 package cmd
 
 import (
-	"encoding/csv"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"log"
+	"io"
+	"math"
 	"os"
+	"time"
 
-	"regexp"
-	"strings"
+	"github.com/dduportal/jenkins-contribution-extractor/internal/output"
+	"github.com/dduportal/jenkins-contribution-extractor/internal/prref"
+	"golang.org/x/sync/errgroup"
 
 	//See https://github.com/schollz/progressbar
 	"github.com/schollz/progressbar/v3"
@@ -37,17 +42,25 @@ import (
 
 // commentersCmd represents the commenters command
 var commentersCmd = &cobra.Command{
-	Use:   "commenters [PR list CSV filename]",
-	Short: "Get the commenters  for a single PR or a set of PRs listed in a CSV file",
-	Long: `Retrieve the Pull Request commenters. 
-It is possible to either pass a (CSV) list of PRs or to specify a single PR. 
+	Use:   "commenters [PR list filename]",
+	Short: "Get the commenters  for a single PR or a set of PRs listed in a file",
+	Long: `Retrieve the Pull Request commenters.
+It is possible to either pass a list of PRs or to specify a single PR.
 
-The CSV list of PRs must be in the form of \"org,repository,number,url,state,created_at,merged_at,user.login,month_year,title\"
-Such a CSV is generated by the jenkins submitter extractions tool (\"jenkins-stats get submitters\").
+The PR list can be a CSV in the form of \"org,repository,number,url,state,created_at,merged_at,user.login,month_year,title\"
+(such a CSV is generated by the jenkins submitter extractions tool, "jenkins-stats get submitters"), a JSON array of
+"{org,repo,number}" objects, or a NDJSON file with one such object per line. The format is auto-detected from the
+file extension, falling back to content sniffing.
 
-To extract the commenters for a single PR, use the "forPR" sub-command. 
+Instead of a file, "--search" accepts a GitHub search query (e.g. "is:pr repo:jenkinsci/jenkins is:merged") and
+materializes the PR list by paginating GitHub's search API.
+
+To extract the commenters for a single PR, use the "forPR" sub-command.
 `,
 	Args: func(cmd *cobra.Command, args []string) error {
+		if searchQuery != "" {
+			return nil
+		}
 		if err := cobra.MinimumNArgs(1)(cmd, args); err != nil {
 			return err
 		}
@@ -57,24 +70,52 @@ To extract the commenters for a single PR, use the "forPR" sub-command.
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		// Debug flag is hidden
-		initLoggers()
-		if isRootDebug {
-			loggers.debug.Println("******** New \"Get Commenters\" debug session ********")
+		logger := initSlog()
+		logger.Debug("new get-commenters session")
+
+		if isDebugEnabled() {
+			limit, remaining, _, _ := get_quota_data_v4()
+			logger.Debug("start quota", "remaining", remaining, "limit", limit)
 		}
 
-		if isRootDebug {
-			fmt.Print("*** Debug mode enabled ***\nSee \"debug.log\" for the trace\n\n")
+		var refs []prref.Ref
+		var inputHash string
+		var err error
+		resume := isResume
 
-			limit, remaining, _, _ := get_quota_data_v4()
-			loggers.debug.Printf("Start quota: %d/%d\n", remaining, limit)
+		if searchQuery != "" {
+			logger.Info("searching PRs", "query", searchQuery)
+			refs, err = searchPrRefs(searchQuery)
+			if err != nil {
+				logger.Error("could not load the PR list", "error", err)
+				os.Exit(1)
+			}
+			inputHash = hashString(searchQuery)
+		} else {
+			refs, err = loadPrRefs(args[0], isVerbose)
+			if err != nil {
+				logger.Error("could not load the PR list", "error", err)
+				os.Exit(1)
+			}
+			inputHash, err = hashFile(args[0])
+			if err != nil {
+				// resume checkpointing can't be trusted without a hash to detect a
+				// changed input, but that's not a reason to refuse to run at all
+				logger.Warn("unable to hash input file, disabling --resume for this run", "path", args[0], "error", err)
+				resume = false
+			}
+		}
+
+		prList := make([]string, len(refs))
+		for i, ref := range refs {
+			prList[i] = ref.Key()
 		}
 
-		performAction(args[0])
+		performAction(prList, inputHash, resume, retryCount, retryBackoff)
 
-		if isRootDebug {
+		if isDebugEnabled() {
 			limit, remaining, _, _ := get_quota_data_v4()
-			loggers.debug.Printf("End quota: %d/%d\n", remaining, limit)
+			logger.Debug("end quota", "remaining", remaining, "limit", limit)
 		}
 	},
 }
@@ -91,107 +132,141 @@ func init() {
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	// commentersCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+
+	commentersCmd.Flags().BoolVar(&isResume, "resume", false, "Resume a previous run, skipping PRs already recorded in the \"<output>.state.json\" sidecar file")
+	commentersCmd.Flags().IntVar(&retryCount, "retry", 3, "Number of retries for a PR, after its first attempt, before giving up on a transient GitHub error")
+	commentersCmd.Flags().DurationVar(&retryBackoff, "retry-backoff", 2*time.Second, "Initial backoff duration between retries (doubled after each attempt)")
+	commentersCmd.Flags().StringVar(&outputFormat, "format", string(output.FormatCSV), "Output format: csv, ndjson, parquet or sqlite")
+	commentersCmd.Flags().IntVar(&workerConcurrency, "concurrency", 1, "Number of PRs processed in parallel")
+	commentersCmd.Flags().StringVar(&searchQuery, "search", "", "GitHub search query used to materialize the PR list instead of reading a file")
+	commentersCmd.Flags().StringVar(&logFormat, "log-format", "text", "Log output format: text or json")
+	commentersCmd.Flags().StringVar(&logLevel, "log-level", "info", "Minimum log level: debug, info, warn or error")
 }
 
+// Flags controlling checkpointing, retry, concurrency and input behaviour, see init()
+var (
+	isResume          bool
+	retryCount        int
+	retryBackoff      time.Duration
+	outputFormat      string
+	workerConcurrency int
+	searchQuery       string
+)
+
+// rateLimitLowWater is the remaining-quota threshold below which the shared
+// rate limiter starts spreading requests over the rest of the reset window
+const rateLimitLowWater = 200
+
 var referenceCSVheader = []string{"org", "repository", "number", "url", "state", "created_at", "merged_at", "user.login", "month_year", "title"}
 
-// Loads the data from a file and try to parse it as a CSV
-func loadPrListFile(fileName string, isVerbose bool) ([]string, bool) {
+// commentersState is the sidecar state persisted alongside the output file so that
+// a run can be resumed after an interruption without re-processing completed PRs.
+type commentersState struct {
+	InputHash         string          `json:"input_hash"`
+	LastProcessedKey  string          `json:"last_processed_key"`
+	ProcessedKeys     map[string]bool `json:"processed_keys"`
+	NbrPRNoComment    int             `json:"nbr_pr_no_comment"`
+	NbrPRWithComments int             `json:"nbr_pr_with_comments"`
+	TotalComments     int             `json:"total_comments"`
+}
+
+// stateFilePath returns the sidecar state file path for a given output file
+func stateFilePath(outputFileName string) string {
+	return outputFileName + ".state.json"
+}
 
+// hashFile returns the sha256 hash (hex encoded) of a file's content, used to
+// detect whether the input CSV changed between two runs of the same output file
+func hashFile(fileName string) (string, error) {
 	f, err := os.Open(fileName)
 	if err != nil {
-		log.Printf("Unable to read input file "+fileName+"\n", err)
-		return nil, false
+		return "", err
 	}
 	defer f.Close()
 
-	r := csv.NewReader(f)
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
 
-	headerLine, err1 := r.Read()
-	if err1 != nil {
-		log.Printf("Unexpected error loading"+fileName+"\n", err)
-		return nil, false
+// loadState loads the sidecar state file for a resumed run. It returns a fresh,
+// empty state (and false) when no sidecar exists yet, or when the input CSV
+// changed since the last run (detected via inputHash), in which case a resume
+// would be unsafe.
+func loadState(path string, inputHash string) (*commentersState, bool) {
+	state := &commentersState{ProcessedKeys: map[string]bool{}}
+
+	if !fileExist(path) {
+		return state, false
 	}
 
-	if isVerbose {
-		fmt.Println("Checking input file")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		appLogger.Warn("unable to read state file, starting fresh", "path", path, "error", err)
+		return state, false
 	}
 
-	if !validateHeader(headerLine, referenceCSVheader, isVerbose) {
-		fmt.Println(" Error: header is incorrect.")
-		return nil, false
-	} else {
-		if isVerbose {
-			fmt.Printf("  - Header is correct\n")
-		}
+	if err := json.Unmarshal(data, state); err != nil {
+		appLogger.Warn("unable to parse state file, starting fresh", "path", path, "error", err)
+		return &commentersState{ProcessedKeys: map[string]bool{}}, false
 	}
 
-	records, err := r.ReadAll()
-	if err != nil {
-		log.Printf("Unexpected error loading \""+fileName+"\"\n", err)
-		return nil, false
+	if state.ProcessedKeys == nil {
+		state.ProcessedKeys = map[string]bool{}
 	}
 
-	if len(records) < 2 {
-		fmt.Printf("Error: No data available after the header\n")
-		return nil, false
+	if state.InputHash != inputHash {
+		appLogger.Warn("input changed since the last run, ignoring previous state", "path", path)
+		return &commentersState{ProcessedKeys: map[string]bool{}}, false
 	}
-	if isVerbose {
-		fmt.Println("  - At least one Pull Request data available")
+
+	return state, true
+}
+
+// saveState persists the current progress to the sidecar state file
+func saveState(path string, state *commentersState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
 	}
+	return os.WriteFile(path, data, 0644)
+}
 
-	var prList []string
-	prj_regexp, _ := regexp.Compile(`^[\w-\.]+$`) // see https://stackoverflow.com/questions/59081778/rules-for-special-characters-in-github-repository-name
-	pr_regexp, _ := regexp.Compile(`^\d+$`)
+// getCommentersWithRetry wraps getCommenters with exponential backoff, retrying
+// transient GitHub errors (e.g. network blips, momentary quota exhaustion) up to
+// maxRetry times instead of letting a single failure abort the whole run. Every
+// attempt's rate-limit accounting is fed back into the shared limiter so
+// concurrent callers throttle before quota runs out.
+func getCommentersWithRetry(pr_line string, limiter *rateLimiter, maxRetry int, backoff time.Duration) ([]output.Commenter, error) {
+	var lastErr error
 
-	// Check the loaded data
-	for _, dataLine := range records {
+	for attempt := 0; attempt <= maxRetry; attempt++ {
+		comments, rl, err := getCommenters(pr_line)
+		limiter.update(rl)
 
-		org := dataLine[0]
-		if !isValidOrgFormat(org) {
-			if isVerbose {
-				fmt.Printf(" Error: ORG field \"%s\" doesn't seem to be a valid GitHub org.\n", org)
-			}
-			if isRootDebug{
-				loggers.debug.Printf(" Error: ORG field \"%s\" doesn't seem to be a valid GitHub org.\n", org)
-			}
-			return nil, false
+		if err == nil {
+			return comments, nil
 		}
+		lastErr = err
 
-		// project name must be "^[\w-\.]+$"
-		prj := dataLine[1]
-		if !prj_regexp.MatchString(strings.ToLower(prj)) {
-			if isVerbose {
-				fmt.Printf(" Error: PRJ field \"%s\" is not of the expected format", prj)
-			}
-			if isRootDebug{
-				loggers.debug.Printf(" Error: PRJ field \"%s\" is not of the expected format", prj)
-			}
-			return nil, false
+		if attempt == maxRetry {
+			break
 		}
 
-		// PR number must be a number
-		prNbr := dataLine[2]
-		if !pr_regexp.MatchString(prNbr) {
-			if isVerbose {
-				fmt.Printf(" Error: PR field \"%s\" is not a (positive) number", prNbr)
-			}
-			if isRootDebug{
-				loggers.debug.Printf(" Error: PR field \"%s\" is not a (positive) number", prNbr)
-			}
-			return nil, false
+		wait := time.Duration(float64(backoff) * math.Pow(2, float64(attempt)))
+		if rl.RetryAfter > wait {
+			wait = rl.RetryAfter
 		}
 
-		prInfo := fmt.Sprintf("%s/%s/%s", org, prj, prNbr)
-		prList = append(prList, prInfo)
-
-	}
-
-	if isVerbose {
-		fmt.Printf("Successfully loaded \"%s\" (%d Pull Request to analyze)\n\n", fileName, len(prList))
+		attrs := append(prLogAttrs(pr_line), "attempt", attempt+1, "max_retry", maxRetry, "remaining_quota", rl.Remaining, "wait", wait, "error", lastErr)
+		appLogger.Debug("transient error, retrying", attrs...)
+		time.Sleep(wait)
 	}
 
-	return prList, true
+	appLogger.Error("giving up on PR", append(prLogAttrs(pr_line), "attempts", maxRetry+1, "error", lastErr)...)
+	return nil, lastErr
 }
 
 // Checks whether the retrieved header is equivalent to the reference header
@@ -217,57 +292,136 @@ func validateHeader(header []string, referenceHeader []string, isVerbose bool) b
 // **************
 
 // This is where it happens
-func performAction(inputFile string) {
+func performAction(prList []string, inputHash string, resume bool, maxRetry int, backoff time.Duration) {
+
+	appLogger.Info("processing pull requests", "count", len(prList))
 
-	fmt.Printf("Processing \"%s\"\n", inputFile)
-	if isRootDebug {
-		loggers.debug.Printf("Processing \"%s\"\n", inputFile)
+	statePath := stateFilePath(outputFileName)
+	var state *commentersState
+	var resumed bool
+	if resume {
+		state, resumed = loadState(statePath, inputHash)
+	} else {
+		state = &commentersState{ProcessedKeys: map[string]bool{}}
+		if fileExist(statePath) {
+			os.Remove(statePath)
+		}
 	}
+	state.InputHash = inputHash
+
+	isAppend := globalIsAppend || resumed
 
-	// read the relevant data from the file (and checking it)
-	prList, result := loadPrListFile(inputFile, isVerbose)
-	if !result {
-		fmt.Printf("Could not load \"%s\"\n", inputFile)
+	w, err := output.NewWriter(output.Format(outputFormat), output.Options{
+		Path:     outputFileName,
+		Append:   isAppend,
+		NoHeader: globalIsNoHeader,
+	})
+	if err != nil {
+		appLogger.Error("unable to open output", "path", outputFileName, "format", outputFormat, "error", err)
 		os.Exit(1)
 	}
+	defer w.Close()
 
-	isAppend := globalIsAppend
-	if !globalIsAppend {
-		// Meaning that we need to create a new file
-		if fileExist(outputFileName) {
-			os.Remove(outputFileName)
+	//check if we have enough quota left to process the remaining PRs
+	remaining := make([]string, 0, len(prList))
+	for _, pr_line := range prList {
+		if !state.ProcessedKeys[pr_line] {
+			remaining = append(remaining, pr_line)
 		}
-		isAppend = true
 	}
-
-	//check if we have enough quota left to process the whole file
-	checkIfSufficientQuota(len(prList))
+	if resumed {
+		appLogger.Info("resuming previous run", "already_processed", len(prList)-len(remaining), "total", len(prList), "remaining", len(remaining))
+	}
+	checkIfSufficientQuota(len(remaining))
 
 	var bar *progressbar.ProgressBar
 	if !isVerbose {
-		bar = progressbar.Default(int64(len(prList)))
+		bar = newCommentersProgressBar(int64(len(remaining)))
 	}
 
-	nbrPR_noComment := 0
-	nbrPR_withComments := 0
-	totalComments := 0
-	for _, pr_line := range prList {
-		//Process the line
-		nbrOfComments := getCommenters(pr_line, isAppend, globalIsNoHeader, outputFileName)
-
-		totalComments = totalComments + nbrOfComments
-		//do some accounting
-		if nbrOfComments == 0 {
-			nbrPR_noComment++
-		} else {
-			nbrPR_withComments++
+	concurrency := workerConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	limiter := newRateLimiter(rateLimitLowWater)
+
+	nbrPR_noComment := state.NbrPRNoComment
+	nbrPR_withComments := state.NbrPRWithComments
+	totalComments := state.TotalComments
+	nbrPR_failed := 0
+
+	// PRs are dispatched to a bounded worker pool, one batch of "concurrency" PRs
+	// at a time. Each batch is flushed to the output, in input order, only once
+	// every worker in it has returned: this keeps the output (and the resume
+	// checkpoint) identical to a sequential run, just faster.
+	for batchStart := 0; batchStart < len(remaining); batchStart += concurrency {
+		batchEnd := batchStart + concurrency
+		if batchEnd > len(remaining) {
+			batchEnd = len(remaining)
+		}
+		batch := remaining[batchStart:batchEnd]
+
+		batchResults := make([][]output.Commenter, len(batch))
+		batchErrs := make([]error, len(batch))
+		g := new(errgroup.Group)
+		for i, pr_line := range batch {
+			i, pr_line := i, pr_line
+			g.Go(func() error {
+				limiter.wait()
+				comments, err := getCommentersWithRetry(pr_line, limiter, maxRetry, backoff)
+				if err != nil {
+					// a PR that keeps failing after exhausting its retries must not take
+					// down the rest of the run; record the error and move on, it's
+					// reported below
+					batchErrs[i] = err
+					return nil
+				}
+				batchResults[i] = comments
+				return nil
+			})
 		}
+		g.Wait()
 
-		// update the progress bar if in quiet mode
-		if !isVerbose {
-			err := bar.Add(1)
-			if err != nil {
-				log.Printf("Unexpected error updating progress bar (%v)\n", err)
+		for i, pr_line := range batch {
+			if err := batchErrs[i]; err != nil {
+				appLogger.Error("skipping PR after exhausting retries", append(prLogAttrs(pr_line), "error", err)...)
+				nbrPR_failed++
+				continue
+			}
+
+			comments := batchResults[i]
+			for _, c := range comments {
+				if err := w.WriteCommenter(c); err != nil {
+					appLogger.Error("unable to write commenter", append(prLogAttrs(pr_line), "error", err)...)
+					os.Exit(1)
+				}
+			}
+
+			totalComments = totalComments + len(comments)
+			//do some accounting
+			if len(comments) == 0 {
+				nbrPR_noComment++
+			} else {
+				nbrPR_withComments++
+			}
+
+			appLogger.Debug("processed PR", append(prLogAttrs(pr_line), "comments", len(comments), "remaining_quota", limiter.Remaining())...)
+
+			state.ProcessedKeys[pr_line] = true
+			state.LastProcessedKey = pr_line
+			state.NbrPRNoComment = nbrPR_noComment
+			state.NbrPRWithComments = nbrPR_withComments
+			state.TotalComments = totalComments
+			if err := saveState(statePath, state); err != nil {
+				appLogger.Warn("unable to persist state file", "path", statePath, "error", err)
+			}
+
+			// update the progress bar if in quiet mode
+			if !isVerbose {
+				err := bar.Add(1)
+				if err != nil {
+					appLogger.Warn("progress bar update failed", "error", err)
+				}
 			}
 		}
 	}
@@ -275,10 +429,11 @@ func performAction(inputFile string) {
 	fmt.Printf("Nbr of PR with comments:    %d\n", nbrPR_withComments)
 	fmt.Printf("Total comments:             %d\n", totalComments)
 
-	if isRootDebug {
-		loggers.debug.Printf("Nbr of PR without comments: %d\n", nbrPR_noComment)
-		loggers.debug.Printf("Nbr of PR with comments:    %d\n", nbrPR_withComments)
-		loggers.debug.Printf("Total comments:             %d\n", totalComments)
-	}
+	appLogger.Debug("final tally", "no_comment", nbrPR_noComment, "with_comments", nbrPR_withComments, "total_comments", totalComments)
 
+	// the run completed successfully, the state file is no longer needed; it is
+	// kept when PRs were skipped, so that "--resume" processes them again
+	if nbrPR_failed == 0 && fileExist(statePath) {
+		os.Remove(statePath)
+	}
 }
@@ -0,0 +1,97 @@
+/*
+Copyright © 2023 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	//See https://github.com/schollz/progressbar
+	"github.com/schollz/progressbar/v3"
+)
+
+// logFormat and logLevel back --log-format/--log-level, see initSlog()
+var (
+	logFormat string
+	logLevel  string
+)
+
+// appLogger is the process-wide structured logger, rebuilt by initSlog() from
+// --log-format/--log-level. It replaces the ad-hoc loggers.debug / log.Printf /
+// fmt.Println calls previously sprinkled through the extraction commands, so
+// operators can ingest one consistent stream of structured logs in CI and grep
+// it by PR instead of regex-parsing free-text debug lines.
+var appLogger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// initSlog (re)builds appLogger from the current --log-format/--log-level flag
+// values and installs it as the slog default
+func initSlog() *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(logLevel)); err != nil {
+		level = slog.LevelInfo
+	}
+
+	var handler slog.Handler
+	if logFormat == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	} else {
+		handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	}
+
+	appLogger = slog.New(handler)
+	slog.SetDefault(appLogger)
+	return appLogger
+}
+
+// isDebugEnabled reports whether appLogger would emit a Debug record, so
+// callers can skip building a debug-only payload (e.g. a quota check) entirely
+func isDebugEnabled() bool {
+	return appLogger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// prLogAttrs splits a "org/repo/number" PR key into the org/repo/pr attributes
+// every per-PR log line carries, so a single PR's trace can be grepped without
+// reconstructing the key
+func prLogAttrs(prKey string) []any {
+	parts := strings.SplitN(prKey, "/", 3)
+	if len(parts) != 3 {
+		return []any{"pr", prKey}
+	}
+	return []any{"org", parts[0], "repo", parts[1], "pr", parts[2]}
+}
+
+// newCommentersProgressBar builds the run's progress indicator. With
+// "--log-format json" the animated bar is suppressed: its carriage-return
+// redraws would interleave with, and corrupt, the JSON log lines sharing
+// stderr. Per-PR progress is still traceable through the debug log lines.
+func newCommentersProgressBar(total int64) *progressbar.ProgressBar {
+	if logFormat == "json" {
+		return progressbar.NewOptions64(total,
+			progressbar.OptionSetWriter(io.Discard),
+			progressbar.OptionSetVisibility(false),
+		)
+	}
+	return progressbar.Default(total)
+}
@@ -0,0 +1,265 @@
+/*
+Copyright © 2023 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dduportal/jenkins-contribution-extractor/internal/prref"
+)
+
+// detectInputFormat tells CSV, JSON and NDJSON PR lists apart, first by
+// extension and, when that's ambiguous, by sniffing the first byte
+func detectInputFormat(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".json":
+		return "json"
+	case ".ndjson", ".jsonl":
+		return "ndjson"
+	case ".csv":
+		return "csv"
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return "csv"
+	}
+	defer f.Close()
+
+	first, err := bufio.NewReader(f).Peek(1)
+	if err == nil && len(first) > 0 {
+		switch first[0] {
+		case '[':
+			return "json"
+		case '{':
+			return "ndjson"
+		}
+	}
+	return "csv"
+}
+
+// loadPrRefs auto-detects fileName's format and loads the Pull Request
+// references it contains
+func loadPrRefs(fileName string, isVerbose bool) ([]prref.Ref, error) {
+	switch detectInputFormat(fileName) {
+	case "json":
+		return loadPrRefsJSON(fileName)
+	case "ndjson":
+		return loadPrRefsNDJSON(fileName)
+	default:
+		refs, _, err := loadPrRefsCSV(fileName, isVerbose)
+		return refs, err
+	}
+}
+
+// loadPrRefsJSON loads a PR list as a single JSON array of {org,repo,number}
+func loadPrRefsJSON(fileName string) ([]prref.Ref, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, &prref.FileError{Path: fileName, Err: err}
+	}
+
+	var refs []prref.Ref
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return nil, &prref.FileError{Path: fileName, Err: err}
+	}
+
+	for i, ref := range refs {
+		if err := prref.ValidateRow(i+1, ref.Org, ref.Repo, strconv.Itoa(ref.Number)); err != nil {
+			return nil, err
+		}
+	}
+
+	return refs, nil
+}
+
+// loadPrRefsNDJSON loads a PR list with one {org,repo,number} object per line
+func loadPrRefsNDJSON(fileName string) ([]prref.Ref, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, &prref.FileError{Path: fileName, Err: err}
+	}
+	defer f.Close()
+
+	var refs []prref.Ref
+	scanner := bufio.NewScanner(f)
+	row := 0
+	for scanner.Scan() {
+		row++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var ref prref.Ref
+		if err := json.Unmarshal([]byte(line), &ref); err != nil {
+			return nil, &prref.RowError{Row: row, Err: err}
+		}
+		if err := prref.ValidateRow(row, ref.Org, ref.Repo, strconv.Itoa(ref.Number)); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, &prref.FileError{Path: fileName, Err: err}
+	}
+
+	return refs, nil
+}
+
+// loadPrRefsCSV keeps the original "jenkins-stats get submitters" CSV shape. It
+// also returns the raw rows, which callers that re-emit the input columns
+// (e.g. "pr-status") need alongside the parsed references.
+func loadPrRefsCSV(fileName string, isVerbose bool) ([]prref.Ref, [][]string, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, nil, &prref.FileError{Path: fileName, Err: err}
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+
+	headerLine, err := r.Read()
+	if err != nil {
+		return nil, nil, &prref.FileError{Path: fileName, Err: err}
+	}
+
+	if isVerbose {
+		fmt.Println("Checking input file")
+	}
+
+	if !validateHeader(headerLine, referenceCSVheader, isVerbose) {
+		return nil, nil, &prref.FileError{Path: fileName, Err: fmt.Errorf("header is incorrect")}
+	}
+	if isVerbose {
+		fmt.Printf("  - Header is correct\n")
+	}
+
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, &prref.FileError{Path: fileName, Err: err}
+	}
+	if len(rows) == 0 {
+		return nil, nil, &prref.FileError{Path: fileName, Err: fmt.Errorf("no data available after the header")}
+	}
+	if isVerbose {
+		fmt.Println("  - At least one Pull Request data available")
+	}
+
+	var refs []prref.Ref
+	for i, dataLine := range rows {
+		if err := prref.ValidateRow(i+1, dataLine[0], dataLine[1], dataLine[2]); err != nil {
+			return nil, nil, err
+		}
+		number, _ := strconv.Atoi(dataLine[2])
+		refs = append(refs, prref.Ref{Org: dataLine[0], Repo: dataLine[1], Number: number})
+	}
+
+	if isVerbose {
+		fmt.Printf("Successfully loaded \"%s\" (%d Pull Request to analyze)\n\n", fileName, len(refs))
+	}
+
+	return refs, rows, nil
+}
+
+// searchPrRefs materializes the PR list by paginating GitHub's search API via
+// GraphQL v4, for users who'd rather pass a search query than maintain a file
+func searchPrRefs(query string) ([]prref.Ref, error) {
+	var refs []prref.Ref
+	cursor := ""
+
+	for {
+		body, err := run_graphql_query_v4(buildPrSearchQuery(query, cursor))
+		if err != nil {
+			return nil, err
+		}
+
+		var parsed struct {
+			Data struct {
+				Search struct {
+					Nodes []struct {
+						Repository struct {
+							Owner struct {
+								Login string `json:"login"`
+							} `json:"owner"`
+							Name string `json:"name"`
+						} `json:"repository"`
+						Number int `json:"number"`
+					} `json:"nodes"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"search"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			return nil, err
+		}
+
+		for _, node := range parsed.Data.Search.Nodes {
+			refs = append(refs, prref.Ref{Org: node.Repository.Owner.Login, Repo: node.Repository.Name, Number: node.Number})
+		}
+
+		if !parsed.Data.Search.PageInfo.HasNextPage {
+			break
+		}
+		cursor = parsed.Data.Search.PageInfo.EndCursor
+	}
+
+	return refs, nil
+}
+
+// buildPrSearchQuery builds the paginated GraphQL v4 search query for a single page
+func buildPrSearchQuery(query string, cursor string) string {
+	after := "null"
+	if cursor != "" {
+		after = fmt.Sprintf("%q", cursor)
+	}
+	return fmt.Sprintf(`query {
+  search(query: %q, type: ISSUE, first: 100, after: %s) {
+    nodes {
+      ... on PullRequest {
+        repository { owner { login } name }
+        number
+      }
+    }
+    pageInfo { hasNextPage endCursor }
+  }
+}`, query, after)
+}
+
+// hashString returns the sha256 hash (hex encoded) of a string, used to detect
+// whether a --search query changed between two runs sharing an output file
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2023 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTempFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+	return path
+}
+
+func TestDetectInputFormat(t *testing.T) {
+	cases := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{"json extension", "prs.json", `[]`, "json"},
+		{"ndjson extension", "prs.ndjson", `{}`, "ndjson"},
+		{"jsonl extension", "prs.jsonl", `{}`, "ndjson"},
+		{"csv extension", "prs.csv", "org,repository,number\n", "csv"},
+		{"no extension, sniffed as json", "prs", `[{"org":"a"}]`, "json"},
+		{"no extension, sniffed as ndjson", "prs", `{"org":"a"}`, "ndjson"},
+		{"no extension, falls back to csv", "prs", "org,repository,number\n", "csv"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := writeTempFile(t, c.file, c.content)
+			if got := detectInputFormat(path); got != c.want {
+				t.Errorf("detectInputFormat(%q) = %q, want %q", c.file, got, c.want)
+			}
+		})
+	}
+}
+
+func TestLoadPrRefsJSON(t *testing.T) {
+	path := writeTempFile(t, "prs.json", `[
+		{"org":"jenkinsci","repo":"jenkins","number":1},
+		{"org":"jenkinsci","repo":"jenkins","number":2}
+	]`)
+
+	refs, err := loadPrRefsJSON(path)
+	if err != nil {
+		t.Fatalf("loadPrRefsJSON returned %v", err)
+	}
+	if len(refs) != 2 {
+		t.Fatalf("got %d refs, want 2", len(refs))
+	}
+	if refs[0].Key() != "jenkinsci/jenkins/1" {
+		t.Errorf("refs[0].Key() = %q, want %q", refs[0].Key(), "jenkinsci/jenkins/1")
+	}
+}
+
+func TestLoadPrRefsJSONInvalidRow(t *testing.T) {
+	path := writeTempFile(t, "prs.json", `[{"org":"bad org","repo":"jenkins","number":1}]`)
+
+	if _, err := loadPrRefsJSON(path); err == nil {
+		t.Fatal("expected an error for an invalid org, got nil")
+	}
+}
+
+func TestLoadPrRefsNDJSON(t *testing.T) {
+	path := writeTempFile(t, "prs.ndjson", "{\"org\":\"jenkinsci\",\"repo\":\"jenkins\",\"number\":1}\n\n{\"org\":\"jenkinsci\",\"repo\":\"jenkins\",\"number\":2}\n")
+
+	refs, err := loadPrRefsNDJSON(path)
+	if err != nil {
+		t.Fatalf("loadPrRefsNDJSON returned %v", err)
+	}
+	if len(refs) != 2 {
+		t.Fatalf("got %d refs, want 2 (blank lines should be skipped)", len(refs))
+	}
+}
+
+func TestLoadPrRefsNDJSONMalformedLine(t *testing.T) {
+	path := writeTempFile(t, "prs.ndjson", "not json\n")
+
+	if _, err := loadPrRefsNDJSON(path); err == nil {
+		t.Fatal("expected an error for a malformed line, got nil")
+	}
+}
+
+func TestHashString(t *testing.T) {
+	if hashString("same query") != hashString("same query") {
+		t.Error("hashString is not deterministic for identical input")
+	}
+	if hashString("query a") == hashString("query b") {
+		t.Error("hashString collided for different input")
+	}
+}
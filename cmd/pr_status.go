@@ -0,0 +1,239 @@
+/*
+Copyright © 2023 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dduportal/jenkins-contribution-extractor/internal/prref"
+
+	//See https://github.com/schollz/progressbar
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+)
+
+// prStatusBatchSize is the number of PRs aliased into a single GraphQL v4 query,
+// chosen to stay comfortably under GitHub's query node-count limits
+const prStatusBatchSize = 100
+
+// prStatusCmd represents the pr-status command
+var prStatusCmd = &cobra.Command{
+	Use:   "pr-status [PR list CSV filename]",
+	Short: "Classify PRs listed in a CSV file by their current GitHub status",
+	Long: `Reads the same PR-list CSV consumed by "commenters" and, for each PR, retrieves
+its current state (open/merged/closed), review decision, mergeable state, check-suite
+conclusion and last-activity timestamp.
+
+Statuses are fetched via GitHub GraphQL v4, batching up to 100 PRs per request (using
+aliased sub-selections) to conserve API quota.
+
+The resulting CSV extends the input header with the new status columns, so it can be
+joined back on "org,repository,number".
+`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.MinimumNArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		if !fileExist(args[0]) {
+			return fmt.Errorf("Invalid file\n")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := initSlog()
+		logger.Debug("new pr-status session")
+
+		performPrStatusAction(args[0])
+	},
+}
+
+func init() {
+	getCmd.AddCommand(prStatusCmd)
+
+	prStatusCmd.Flags().StringVar(&logFormat, "log-format", "text", "Log output format: text or json")
+	prStatusCmd.Flags().StringVar(&logLevel, "log-level", "info", "Minimum log level: debug, info, warn or error")
+}
+
+// statusCSVheader is the output header: the reference input header followed by
+// the status columns
+var statusCSVheader = append(append([]string{}, referenceCSVheader...), "status", "review_decision", "mergeable_state", "check_conclusion", "last_activity")
+
+// prStatus holds the GitHub-reported status of a single PR
+type prStatus struct {
+	state           string
+	reviewDecision  string
+	mergeableState  string
+	checkConclusion string
+	lastActivity    string
+}
+
+// buildPrStatusQuery builds a single GraphQL v4 query that aliases one sub-selection
+// per PR in the batch, so up to prStatusBatchSize PRs are fetched per round-trip
+func buildPrStatusQuery(batch []prref.Ref) string {
+	query := "query {\n"
+	for i, ref := range batch {
+		query += fmt.Sprintf(`  pr%d: repository(owner: %q, name: %q) {
+    pullRequest(number: %d) {
+      state
+      reviewDecision
+      mergeable
+      updatedAt
+      commits(last: 1) {
+        nodes {
+          commit {
+            statusCheckRollup {
+              state
+            }
+          }
+        }
+      }
+    }
+  }
+`, i, ref.Org, ref.Repo, ref.Number)
+	}
+	query += "}"
+	return query
+}
+
+// fetchPrStatuses resolves the status of a batch (<= prStatusBatchSize) of PRs in a
+// single GraphQL v4 request, returning results indexed the same way as the batch
+func fetchPrStatuses(batch []prref.Ref) ([]prStatus, error) {
+	query := buildPrStatusQuery(batch)
+
+	body, err := run_graphql_query_v4(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Data map[string]struct {
+			PullRequest struct {
+				State          string `json:"state"`
+				ReviewDecision string `json:"reviewDecision"`
+				Mergeable      string `json:"mergeable"`
+				UpdatedAt      string `json:"updatedAt"`
+				Commits        struct {
+					Nodes []struct {
+						Commit struct {
+							StatusCheckRollup struct {
+								State string `json:"state"`
+							} `json:"statusCheckRollup"`
+						} `json:"commit"`
+					} `json:"nodes"`
+				} `json:"commits"`
+			} `json:"pullRequest"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]prStatus, len(batch))
+	for i := range batch {
+		entry := parsed.Data[fmt.Sprintf("pr%d", i)]
+		checkConclusion := ""
+		if len(entry.PullRequest.Commits.Nodes) > 0 {
+			checkConclusion = entry.PullRequest.Commits.Nodes[0].Commit.StatusCheckRollup.State
+		}
+		statuses[i] = prStatus{
+			state:           entry.PullRequest.State,
+			reviewDecision:  entry.PullRequest.ReviewDecision,
+			mergeableState:  entry.PullRequest.Mergeable,
+			checkConclusion: checkConclusion,
+			lastActivity:    entry.PullRequest.UpdatedAt,
+		}
+	}
+
+	return statuses, nil
+}
+
+// This is where it happens
+func performPrStatusAction(inputFile string) {
+
+	appLogger.Info("processing pull requests", "input", inputFile)
+
+	refs, rows, err := loadPrRefsCSV(inputFile, isVerbose)
+	if err != nil {
+		appLogger.Error("could not load the PR list", "input", inputFile, "error", err)
+		os.Exit(1)
+	}
+
+	if fileExist(outputFileName) {
+		os.Remove(outputFileName)
+	}
+	out, err := os.Create(outputFileName)
+	if err != nil {
+		appLogger.Error("unable to create output", "path", outputFileName, "error", err)
+		os.Exit(1)
+	}
+	defer out.Close()
+
+	w := csv.NewWriter(out)
+	defer w.Flush()
+
+	if !globalIsNoHeader {
+		if err := w.Write(statusCSVheader); err != nil {
+			appLogger.Error("unable to write header", "path", outputFileName, "error", err)
+			os.Exit(1)
+		}
+	}
+
+	var bar *progressbar.ProgressBar
+	if !isVerbose {
+		bar = newCommentersProgressBar(int64(len(refs)))
+	}
+
+	for start := 0; start < len(refs); start += prStatusBatchSize {
+		end := start + prStatusBatchSize
+		if end > len(refs) {
+			end = len(refs)
+		}
+
+		statuses, err := fetchPrStatuses(refs[start:end])
+		if err != nil {
+			appLogger.Error("unable to fetch status for batch", "start", start, "end", end, "error", err)
+			os.Exit(1)
+		}
+
+		for i, status := range statuses {
+			ref := refs[start+i]
+			row := append(append([]string{}, rows[start+i]...), status.state, status.reviewDecision, status.mergeableState, status.checkConclusion, status.lastActivity)
+			if err := w.Write(row); err != nil {
+				appLogger.Error("unable to write row", "org", ref.Org, "repo", ref.Repo, "pr", ref.Number, "error", err)
+				os.Exit(1)
+			}
+			appLogger.Debug("fetched PR status", "org", ref.Org, "repo", ref.Repo, "pr", ref.Number, "status", status.state)
+		}
+
+		if !isVerbose {
+			if err := bar.Add(end - start); err != nil {
+				appLogger.Warn("progress bar update failed", "error", err)
+			}
+		}
+	}
+
+	fmt.Printf("Nbr of PR processed: %d\n", len(refs))
+	appLogger.Debug("final tally", "processed", len(refs))
+}
@@ -0,0 +1,109 @@
+/*
+Copyright © 2023 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimitHeaders carries the GitHub GraphQL v4 rate-limit accounting returned
+// alongside a response, so callers can throttle before it turns into a hard error
+type rateLimitHeaders struct {
+	Limit      int
+	Remaining  int
+	ResetAt    time.Time
+	RetryAfter time.Duration
+}
+
+// rateLimiter is a shared, adaptive token bucket: it spreads the remaining
+// GitHub quota evenly over the time left until the quota window resets, and
+// honours GitHub's secondary rate limit (Retry-After) when signalled.
+type rateLimiter struct {
+	mu            sync.Mutex
+	remaining     int
+	resetAt       time.Time
+	blockedUntil  time.Time
+	lowWaterLimit int
+}
+
+// newRateLimiter creates a rate limiter that starts unthrottled until the first
+// response updates it with real GitHub accounting
+func newRateLimiter(lowWaterLimit int) *rateLimiter {
+	return &rateLimiter{lowWaterLimit: lowWaterLimit}
+}
+
+// update records the quota accounting observed in the last GraphQL response
+func (rl *rateLimiter) update(h rateLimitHeaders) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.remaining = h.Remaining
+	rl.resetAt = h.ResetAt
+	if h.RetryAfter > 0 {
+		until := time.Now().Add(h.RetryAfter)
+		if until.After(rl.blockedUntil) {
+			rl.blockedUntil = until
+		}
+	}
+}
+
+// Remaining reports the last known remaining quota, for diagnostics (e.g. log lines)
+func (rl *rateLimiter) Remaining() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.remaining
+}
+
+// wait blocks the calling worker, if needed, before it is allowed to issue its
+// next GraphQL request: either because GitHub asked for a secondary-rate-limit
+// cooldown, or because spending quota at the current pace would exhaust it
+// before the window resets.
+func (rl *rateLimiter) wait() {
+	rl.mu.Lock()
+	blockedUntil := rl.blockedUntil
+	remaining := rl.remaining
+	resetAt := rl.resetAt
+	lowWater := rl.lowWaterLimit
+	rl.mu.Unlock()
+
+	if until := time.Until(blockedUntil); until > 0 {
+		time.Sleep(until)
+	}
+
+	timeToReset := time.Until(resetAt)
+	if timeToReset <= 0 {
+		return
+	}
+
+	if remaining <= 0 {
+		// quota is hard-exhausted: wait out the rest of the window instead of
+		// letting workers keep firing requests that are doomed to fail
+		time.Sleep(timeToReset)
+		return
+	}
+
+	if remaining <= lowWater {
+		// spread what's left evenly across the remaining window
+		time.Sleep(timeToReset / time.Duration(remaining))
+	}
+}
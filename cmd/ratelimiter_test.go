@@ -0,0 +1,94 @@
+/*
+Copyright © 2023 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterUpdateTracksRemaining(t *testing.T) {
+	rl := newRateLimiter(200)
+	rl.update(rateLimitHeaders{Remaining: 4500, ResetAt: time.Now().Add(time.Hour)})
+
+	if got := rl.Remaining(); got != 4500 {
+		t.Errorf("Remaining() = %d, want 4500", got)
+	}
+}
+
+func TestRateLimiterWaitUnthrottledAboveLowWater(t *testing.T) {
+	rl := newRateLimiter(200)
+	rl.update(rateLimitHeaders{Remaining: 5000, ResetAt: time.Now().Add(time.Hour)})
+
+	start := time.Now()
+	rl.wait()
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("wait() blocked for %v, want it to return immediately while well above the low-water mark", elapsed)
+	}
+}
+
+func TestRateLimiterWaitSpreadsNearLowWater(t *testing.T) {
+	rl := newRateLimiter(200)
+	// 100 requests left to spend over the next 100ms: wait() should sleep
+	// roughly 1ms so the remaining quota lasts until the window resets.
+	rl.update(rateLimitHeaders{Remaining: 100, ResetAt: time.Now().Add(100 * time.Millisecond)})
+
+	start := time.Now()
+	rl.wait()
+	elapsed := time.Since(start)
+	if elapsed < 500*time.Microsecond || elapsed > 50*time.Millisecond {
+		t.Errorf("wait() slept %v, want roughly 1ms (timeToReset/remaining)", elapsed)
+	}
+}
+
+func TestRateLimiterWaitSleepsUntilResetWhenExhausted(t *testing.T) {
+	rl := newRateLimiter(200)
+	rl.update(rateLimitHeaders{Remaining: 0, ResetAt: time.Now().Add(20 * time.Millisecond)})
+
+	start := time.Now()
+	rl.wait()
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("wait() returned after %v with quota fully exhausted, want it to sleep until resetAt", elapsed)
+	}
+}
+
+func TestRateLimiterWaitReturnsPastReset(t *testing.T) {
+	rl := newRateLimiter(200)
+	rl.update(rateLimitHeaders{Remaining: 0, ResetAt: time.Now().Add(-time.Minute)})
+
+	start := time.Now()
+	rl.wait()
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("wait() blocked for %v after the reset window already passed", elapsed)
+	}
+}
+
+func TestRateLimiterWaitHonoursRetryAfter(t *testing.T) {
+	rl := newRateLimiter(200)
+	rl.update(rateLimitHeaders{Remaining: 5000, ResetAt: time.Now().Add(time.Hour), RetryAfter: 20 * time.Millisecond})
+
+	start := time.Now()
+	rl.wait()
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("wait() returned after %v, want it to honour the %v secondary rate-limit cooldown", elapsed, 20*time.Millisecond)
+	}
+}
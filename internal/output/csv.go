@@ -0,0 +1,83 @@
+/*
+Copyright © 2023 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package output
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+)
+
+var commenterCSVheader = []string{"org", "repo", "pr", "comment_id", "login", "created_at", "body"}
+
+// csvWriter is the historical output backend: one row appended per commenter
+type csvWriter struct {
+	file *os.File
+	w    *csv.Writer
+}
+
+func newCSVWriter(opts Options) (Writer, error) {
+	flags := os.O_CREATE | os.O_WRONLY
+	writeHeader := !opts.NoHeader
+	if opts.Append {
+		flags |= os.O_APPEND
+		if _, err := os.Stat(opts.Path); err == nil {
+			writeHeader = false
+		}
+	} else {
+		flags |= os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(opts.Path, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	w := csv.NewWriter(f)
+	if writeHeader {
+		if err := w.Write(commenterCSVheader); err != nil {
+			f.Close()
+			return nil, err
+		}
+		w.Flush()
+	}
+
+	return &csvWriter{file: f, w: w}, nil
+}
+
+func (cw *csvWriter) WriteCommenter(c Commenter) error {
+	if err := cw.w.Write([]string{c.Org, c.Repo, strconv.Itoa(c.PR), strconv.FormatInt(c.CommentID, 10), c.Login, c.CreatedAt, c.Body}); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	if err := cw.w.Error(); err != nil {
+		cw.file.Close()
+		return err
+	}
+	return cw.file.Close()
+}
@@ -0,0 +1,80 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCSVWriterHeaderAndRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+
+	w, err := newCSVWriter(Options{Path: path})
+	if err != nil {
+		t.Fatalf("newCSVWriter returned %v", err)
+	}
+	if err := w.WriteCommenter(Commenter{Org: "jenkinsci", Repo: "jenkins", PR: 1, CommentID: 101, Login: "alice", CreatedAt: "2023-01-01T00:00:00Z", Body: "hi"}); err != nil {
+		t.Fatalf("WriteCommenter returned %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	want := "org,repo,pr,comment_id,login,created_at,body\njenkinsci,jenkins,1,101,alice,2023-01-01T00:00:00Z,hi\n"
+	if string(data) != want {
+		t.Errorf("file content = %q, want %q", string(data), want)
+	}
+}
+
+func TestCSVWriterNoHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+
+	w, err := newCSVWriter(Options{Path: path, NoHeader: true})
+	if err != nil {
+		t.Fatalf("newCSVWriter returned %v", err)
+	}
+	w.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	if len(data) != 0 {
+		t.Errorf("file content = %q, want empty (no header, no rows)", string(data))
+	}
+}
+
+func TestCSVWriterAppendSkipsHeaderOnExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+
+	w1, err := newCSVWriter(Options{Path: path})
+	if err != nil {
+		t.Fatalf("newCSVWriter returned %v", err)
+	}
+	if err := w1.WriteCommenter(Commenter{Org: "a", Repo: "b", PR: 1, CommentID: 1, Login: "x", CreatedAt: "t1", Body: "first"}); err != nil {
+		t.Fatalf("WriteCommenter returned %v", err)
+	}
+	w1.Close()
+
+	w2, err := newCSVWriter(Options{Path: path, Append: true})
+	if err != nil {
+		t.Fatalf("newCSVWriter (append) returned %v", err)
+	}
+	if err := w2.WriteCommenter(Commenter{Org: "a", Repo: "b", PR: 1, CommentID: 2, Login: "y", CreatedAt: "t2", Body: "second"}); err != nil {
+		t.Fatalf("WriteCommenter returned %v", err)
+	}
+	w2.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	want := "org,repo,pr,comment_id,login,created_at,body\na,b,1,1,x,t1,first\na,b,1,2,y,t2,second\n"
+	if string(data) != want {
+		t.Errorf("file content = %q, want %q", string(data), want)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright © 2023 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package output
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// ndjsonWriter streams one JSON object per commenter, one per line
+type ndjsonWriter struct {
+	file *os.File
+	enc  *json.Encoder
+}
+
+func newNDJSONWriter(opts Options) (Writer, error) {
+	flags := os.O_CREATE | os.O_WRONLY
+	if opts.Append {
+		flags |= os.O_APPEND
+	} else {
+		flags |= os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(opts.Path, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ndjsonWriter{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (nw *ndjsonWriter) WriteCommenter(c Commenter) error {
+	return nw.enc.Encode(c)
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nw.file.Close()
+}
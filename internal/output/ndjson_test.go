@@ -0,0 +1,67 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNDJSONWriterOneObjectPerLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.ndjson")
+
+	w, err := newNDJSONWriter(Options{Path: path})
+	if err != nil {
+		t.Fatalf("newNDJSONWriter returned %v", err)
+	}
+	if err := w.WriteCommenter(Commenter{Org: "jenkinsci", Repo: "jenkins", PR: 1, CommentID: 101, Login: "alice", Body: "hi"}); err != nil {
+		t.Fatalf("WriteCommenter returned %v", err)
+	}
+	if err := w.WriteCommenter(Commenter{Org: "jenkinsci", Repo: "jenkins", PR: 1, CommentID: 102, Login: "bob", Body: "there"}); err != nil {
+		t.Fatalf("WriteCommenter returned %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	var first Commenter
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("unmarshalling first line: %v", err)
+	}
+	if first.Login != "alice" || first.CommentID != 101 {
+		t.Errorf("first line = %+v, want login alice, comment_id 101", first)
+	}
+}
+
+func TestNDJSONWriterAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.ndjson")
+
+	w1, _ := newNDJSONWriter(Options{Path: path})
+	w1.WriteCommenter(Commenter{Login: "alice"})
+	w1.Close()
+
+	w2, err := newNDJSONWriter(Options{Path: path, Append: true})
+	if err != nil {
+		t.Fatalf("newNDJSONWriter (append) returned %v", err)
+	}
+	w2.WriteCommenter(Commenter{Login: "bob"})
+	w2.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines after append, want 2: %q", len(lines), string(data))
+	}
+}
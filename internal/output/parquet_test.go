@@ -0,0 +1,29 @@
+package output
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParquetWriterRejectsAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.parquet")
+
+	if _, err := newParquetWriter(Options{Path: path, Append: true}); err == nil {
+		t.Fatal("newParquetWriter with Append = true returned no error, want one: a Parquet file can't be appended to or resumed")
+	}
+}
+
+func TestParquetWriterWritesRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.parquet")
+
+	w, err := newParquetWriter(Options{Path: path})
+	if err != nil {
+		t.Fatalf("newParquetWriter returned %v", err)
+	}
+	if err := w.WriteCommenter(Commenter{Org: "jenkinsci", Repo: "jenkins", PR: 1, CommentID: 101, Login: "alice", CreatedAt: "2023-01-01T00:00:00Z", Body: "hi"}); err != nil {
+		t.Fatalf("WriteCommenter returned %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned %v", err)
+	}
+}
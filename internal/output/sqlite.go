@@ -0,0 +1,87 @@
+/*
+Copyright © 2023 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package output
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+const createCommentersTableSQL = `
+CREATE TABLE IF NOT EXISTS commenters (
+	org         TEXT NOT NULL,
+	repo        TEXT NOT NULL,
+	pr          INTEGER NOT NULL,
+	comment_id  INTEGER NOT NULL,
+	login       TEXT NOT NULL,
+	created_at  TEXT NOT NULL,
+	body        TEXT NOT NULL,
+	PRIMARY KEY (org, repo, pr, comment_id)
+);
+CREATE INDEX IF NOT EXISTS idx_commenters_org_repo_pr ON commenters (org, repo, pr);
+`
+
+const upsertCommenterSQL = `
+INSERT INTO commenters (org, repo, pr, comment_id, login, created_at, body)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (org, repo, pr, comment_id) DO UPDATE SET body = excluded.body;
+`
+
+// sqliteWriter writes into a single "commenters" table, re-runs UPSERT rather
+// than duplicate rows
+type sqliteWriter struct {
+	db   *sql.DB
+	stmt *sql.Stmt
+}
+
+func newSQLiteWriter(opts Options) (Writer, error) {
+	db, err := sql.Open("sqlite", opts.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(createCommentersTableSQL); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating commenters table: %w", err)
+	}
+
+	stmt, err := db.Prepare(upsertCommenterSQL)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &sqliteWriter{db: db, stmt: stmt}, nil
+}
+
+func (sw *sqliteWriter) WriteCommenter(c Commenter) error {
+	_, err := sw.stmt.Exec(c.Org, c.Repo, c.PR, c.CommentID, c.Login, c.CreatedAt, c.Body)
+	return err
+}
+
+func (sw *sqliteWriter) Close() error {
+	sw.stmt.Close()
+	return sw.db.Close()
+}
@@ -0,0 +1,97 @@
+package output
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestSQLiteWriterUpsertsSameCommentID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.sqlite")
+
+	w, err := newSQLiteWriter(Options{Path: path})
+	if err != nil {
+		t.Fatalf("newSQLiteWriter returned %v", err)
+	}
+
+	c := Commenter{Org: "jenkinsci", Repo: "jenkins", PR: 1, CommentID: 101, Login: "alice", CreatedAt: "2023-01-01T00:00:00Z", Body: "first version"}
+	if err := w.WriteCommenter(c); err != nil {
+		t.Fatalf("WriteCommenter returned %v", err)
+	}
+
+	// a re-run over the same input re-sends the same comment, possibly edited
+	c.Body = "edited version"
+	if err := w.WriteCommenter(c); err != nil {
+		t.Fatalf("WriteCommenter (re-run) returned %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned %v", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open returned %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM commenters").Scan(&count); err != nil {
+		t.Fatalf("counting rows: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("got %d rows after upserting the same comment_id twice, want 1", count)
+	}
+
+	var body string
+	if err := db.QueryRow("SELECT body FROM commenters WHERE comment_id = 101").Scan(&body); err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	if body != "edited version" {
+		t.Errorf("body = %q, want the latest write to win (%q)", body, "edited version")
+	}
+}
+
+func TestSQLiteWriterKeepsDistinctCommentsInSameSecond(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.sqlite")
+
+	w, err := newSQLiteWriter(Options{Path: path})
+	if err != nil {
+		t.Fatalf("newSQLiteWriter returned %v", err)
+	}
+
+	// two different comments by the same user on the same PR, same second,
+	// must not collide now that comment_id (not created_at) is part of the key
+	same := Commenter{Org: "jenkinsci", Repo: "jenkins", PR: 1, Login: "alice", CreatedAt: "2023-01-01T00:00:00Z"}
+	first := same
+	first.CommentID = 101
+	first.Body = "first comment"
+	second := same
+	second.CommentID = 102
+	second.Body = "second comment"
+
+	if err := w.WriteCommenter(first); err != nil {
+		t.Fatalf("WriteCommenter returned %v", err)
+	}
+	if err := w.WriteCommenter(second); err != nil {
+		t.Fatalf("WriteCommenter returned %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned %v", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open returned %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM commenters").Scan(&count); err != nil {
+		t.Fatalf("counting rows: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("got %d rows for two distinct same-second comments, want 2", count)
+	}
+}
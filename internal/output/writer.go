@@ -0,0 +1,92 @@
+/*
+Copyright © 2023 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package output provides the pluggable destinations that extraction commands
+// (e.g. "get commenters") can write their results to: CSV, NDJSON, Parquet and
+// SQLite, all implementing the same Writer interface.
+package output
+
+import "fmt"
+
+// Format identifies one of the supported output backends
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatNDJSON  Format = "ndjson"
+	FormatParquet Format = "parquet"
+	FormatSQLite  Format = "sqlite"
+)
+
+// Commenter is a single row of the commenter extraction: one comment by one user
+// on one PR
+type Commenter struct {
+	Org  string `json:"org"`
+	Repo string `json:"repo"`
+	PR   int    `json:"pr"`
+	// CommentID is GitHub's own (numeric, databaseId) identifier for the comment.
+	// created_at only has second resolution, so two distinct comments from the
+	// same user on the same PR within the same second are otherwise
+	// indistinguishable; backends that dedupe on re-runs (e.g. SQLite) key on
+	// org, repo, pr and this, instead of on login and created_at.
+	CommentID int64  `json:"comment_id"`
+	Login     string `json:"login"`
+	CreatedAt string `json:"created_at"`
+	Body      string `json:"body"`
+}
+
+// Writer is implemented by every output backend. WriteCommenter is called once
+// per extracted comment; Close flushes and releases any underlying resource.
+// Implementations must be safe to call WriteCommenter repeatedly across a long
+// batch run without re-opening the destination each time.
+type Writer interface {
+	WriteCommenter(c Commenter) error
+	Close() error
+}
+
+// Options configures the construction of a Writer
+type Options struct {
+	// Path is the destination file (CSV/NDJSON/Parquet) or database (SQLite)
+	Path string
+	// Append, when true and the destination already exists, adds to it instead
+	// of truncating. SQLite always upserts regardless of this flag; Parquet
+	// returns an error when it is set.
+	Append bool
+	// NoHeader suppresses the header row for the CSV backend
+	NoHeader bool
+}
+
+// NewWriter builds the Writer for the requested format
+func NewWriter(format Format, opts Options) (Writer, error) {
+	switch format {
+	case FormatCSV, "":
+		return newCSVWriter(opts)
+	case FormatNDJSON:
+		return newNDJSONWriter(opts)
+	case FormatParquet:
+		return newParquetWriter(opts)
+	case FormatSQLite:
+		return newSQLiteWriter(opts)
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", format)
+	}
+}
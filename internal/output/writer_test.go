@@ -0,0 +1,36 @@
+package output
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNewWriterDispatchesByFormat(t *testing.T) {
+	cases := []struct {
+		format  Format
+		wantErr bool
+	}{
+		{FormatCSV, false},
+		{"", false}, // defaults to CSV
+		{FormatNDJSON, false},
+		{FormatSQLite, false},
+		{"bogus", true},
+	}
+	for _, c := range cases {
+		path := filepath.Join(t.TempDir(), "out")
+		w, err := NewWriter(c.format, Options{Path: path})
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("NewWriter(%q, ...) returned no error, want one", c.format)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NewWriter(%q, ...) returned %v", c.format, err)
+			continue
+		}
+		if err := w.Close(); err != nil {
+			t.Errorf("Close() after NewWriter(%q, ...) returned %v", c.format, err)
+		}
+	}
+}
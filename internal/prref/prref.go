@@ -0,0 +1,100 @@
+/*
+Copyright © 2023 Jean-Marc Meessen jean-marc@meessen-web.org
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package prref holds the Pull Request reference type and the sanity checks
+// shared by every input path (CSV, JSON, NDJSON, GitHub search) that can feed
+// the extractor's commands, so they all apply the same rules.
+package prref
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	orgRegexp     = regexp.MustCompile(`^[\w-]+$`)
+	projectRegexp = regexp.MustCompile(`^[\w\-\.]+$`) // see https://stackoverflow.com/questions/59081778/rules-for-special-characters-in-github-repository-name
+	numberRegexp  = regexp.MustCompile(`^\d+$`)
+)
+
+// Ref uniquely identifies a single Pull Request
+type Ref struct {
+	Org    string `json:"org"`
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+}
+
+// Key returns the "org/repo/number" form used throughout the extractor
+func (r Ref) Key() string {
+	return fmt.Sprintf("%s/%s/%d", r.Org, r.Repo, r.Number)
+}
+
+// FileError reports that an input couldn't be read or recognised as a whole,
+// as opposed to a single malformed row within an otherwise valid input
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string { return fmt.Sprintf("%s: %v", e.Path, e.Err) }
+func (e *FileError) Unwrap() error { return e.Err }
+
+// RowError reports that a single row is malformed. Row is 1-based and excludes
+// any header line.
+type RowError struct {
+	Row int
+	Err error
+}
+
+func (e *RowError) Error() string { return fmt.Sprintf("row %d: %v", e.Row, e.Err) }
+func (e *RowError) Unwrap() error { return e.Err }
+
+// IsValidOrg reports whether org looks like a valid GitHub organization/user name
+func IsValidOrg(org string) bool {
+	return orgRegexp.MatchString(org)
+}
+
+// IsValidProject reports whether project looks like a valid GitHub repository name
+func IsValidProject(project string) bool {
+	return projectRegexp.MatchString(strings.ToLower(project))
+}
+
+// IsValidNumber reports whether number is a valid PR number: a non-empty run
+// of decimal digits, so "0" passes and a sign does not
+func IsValidNumber(number string) bool {
+	return numberRegexp.MatchString(number)
+}
+
+// ValidateRow checks the three identifying fields of a PR row and returns a
+// *RowError describing the first one that doesn't pass, or nil
+func ValidateRow(row int, org string, project string, number string) error {
+	switch {
+	case !IsValidOrg(org):
+		return &RowError{Row: row, Err: fmt.Errorf("ORG field %q doesn't seem to be a valid GitHub org", org)}
+	case !IsValidProject(project):
+		return &RowError{Row: row, Err: fmt.Errorf("PRJ field %q is not of the expected format", project)}
+	case !IsValidNumber(number):
+		return &RowError{Row: row, Err: fmt.Errorf("PR field %q is not a (positive) number", number)}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package prref
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIsValidOrg(t *testing.T) {
+	cases := []struct {
+		org  string
+		want bool
+	}{
+		{"jenkinsci", true},
+		{"jenkins-x", true},
+		{"jenkins_x", true},
+		{"", false},
+		{"jenkins/x", false},
+		{"jenkins x", false},
+	}
+	for _, c := range cases {
+		if got := IsValidOrg(c.org); got != c.want {
+			t.Errorf("IsValidOrg(%q) = %v, want %v", c.org, got, c.want)
+		}
+	}
+}
+
+func TestIsValidProject(t *testing.T) {
+	cases := []struct {
+		project string
+		want    bool
+	}{
+		{"jenkins", true},
+		{"configuration-as-code", true},
+		{"some.plugin", true},
+		{"Some-Plugin", true}, // matched case-insensitively
+		{"", false},
+		{"jenkins/plugin", false},
+	}
+	for _, c := range cases {
+		if got := IsValidProject(c.project); got != c.want {
+			t.Errorf("IsValidProject(%q) = %v, want %v", c.project, got, c.want)
+		}
+	}
+}
+
+func TestIsValidNumber(t *testing.T) {
+	cases := []struct {
+		number string
+		want   bool
+	}{
+		{"1", true},
+		{"12345", true},
+		{"0", true},
+		{"", false},
+		{"-1", false},
+		{"12a", false},
+	}
+	for _, c := range cases {
+		if got := IsValidNumber(c.number); got != c.want {
+			t.Errorf("IsValidNumber(%q) = %v, want %v", c.number, got, c.want)
+		}
+	}
+}
+
+func TestValidateRow(t *testing.T) {
+	if err := ValidateRow(1, "jenkinsci", "jenkins", "123"); err != nil {
+		t.Fatalf("ValidateRow on a valid row returned %v", err)
+	}
+
+	cases := []struct {
+		name             string
+		org, prj, number string
+	}{
+		{"bad org", "jenkins/x", "jenkins", "123"},
+		{"bad project", "jenkinsci", "jenkins/x", "123"},
+		{"bad number", "jenkinsci", "jenkins", "abc"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateRow(7, c.org, c.prj, c.number)
+			if err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+			var rowErr *RowError
+			if !errors.As(err, &rowErr) {
+				t.Fatalf("expected a *RowError, got %T", err)
+			}
+			if rowErr.Row != 7 {
+				t.Errorf("RowError.Row = %d, want 7", rowErr.Row)
+			}
+		})
+	}
+}
+
+func TestRefKey(t *testing.T) {
+	r := Ref{Org: "jenkinsci", Repo: "jenkins", Number: 42}
+	if got, want := r.Key(), "jenkinsci/jenkins/42"; got != want {
+		t.Errorf("Key() = %q, want %q", got, want)
+	}
+}
+
+func TestFileErrorUnwrap(t *testing.T) {
+	wrapped := errors.New("permission denied")
+	err := &FileError{Path: "prs.csv", Err: wrapped}
+
+	if !errors.Is(err, wrapped) {
+		t.Errorf("errors.Is(err, wrapped) = false, want true")
+	}
+	if want := "prs.csv: permission denied"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestRowErrorUnwrap(t *testing.T) {
+	wrapped := errors.New("not a number")
+	err := &RowError{Row: 3, Err: wrapped}
+
+	if !errors.Is(err, wrapped) {
+		t.Errorf("errors.Is(err, wrapped) = false, want true")
+	}
+	if want := "row 3: not a number"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}